@@ -18,17 +18,24 @@ package resources
 
 import (
 	"fmt"
+	"sync"
 	"time"
 
 	"github.com/aws/aws-sdk-go/aws"
 	cf "github.com/aws/aws-sdk-go/service/cloudformation"
+	rgta "github.com/aws/aws-sdk-go/service/resourcegroupstaggingapi"
 	"github.com/pkg/errors"
 	"github.com/sirupsen/logrus"
+	"golang.org/x/sync/errgroup"
 )
 
 // Cloud Formation Stacks
 type CloudFormationStacks struct{}
 
+// cloudFormationStackResourceType is this type's identifier in filter files
+// (see Filter) and in the structured sweep report (see Set.Report).
+const cloudFormationStackResourceType = "cloudformation_stack"
+
 func (CloudFormationStacks) fetchTags(svc *cf.CloudFormation, stackID string, logger logrus.FieldLogger) ([]Tag, error) {
 	var tags []Tag
 
@@ -49,11 +56,37 @@ func (CloudFormationStacks) fetchTags(svc *cf.CloudFormation, stackID string, lo
 	return tags, err
 }
 
+// tagForDeletion applies tag to the stack via the resource tagging API,
+// which (unlike cf.UpdateStack) doesn't require re-supplying the stack's
+// existing parameters and capabilities. It is a no-op under DryRun, since
+// applying the marker tag is itself a mutation.
+func (CloudFormationStacks) tagForDeletion(opts Options, arn string, tag Tag) error {
+	if opts.DryRun {
+		return nil
+	}
+	svc := rgta.New(opts.Session, aws.NewConfig().WithRegion(opts.Region))
+	_, err := svc.TagResources(&rgta.TagResourcesInput{
+		ResourceARNList: []*string{aws.String(arn)},
+		Tags:            map[string]*string{tag.Key: aws.String(tag.Value)},
+	})
+	return err
+}
+
+// parallelism returns how many goroutines a bounded worker pool should use
+// for opts, defaulting to serial (1) for backward compatibility.
+func parallelism(opts Options) int {
+	if opts.Parallelism < 1 {
+		return 1
+	}
+	return opts.Parallelism
+}
+
 func (cfs CloudFormationStacks) MarkAndSweep(opts Options, set *Set) error {
 	logger := logrus.WithField("options", opts)
 	svc := cf.New(opts.Session, aws.NewConfig().WithRegion(opts.Region))
 
-	var toDelete []*cloudFormationStack // Paged call, defer deletion until we have the whole list.
+	var candidates []*cloudFormationStack
+	var createdTimes []*time.Time // parallel to candidates
 
 	pageFunc := func(page *cf.ListStacksOutput, _ bool) bool {
 		for _, stack := range page.StackSummaries {
@@ -64,39 +97,89 @@ func (cfs CloudFormationStacks) MarkAndSweep(opts Options, set *Set) error {
 				cf.ResourceStatusDeleteInProgress:
 				continue
 			}
-			o := &cloudFormationStack{
+			candidates = append(candidates, &cloudFormationStack{
 				account: opts.Account,
 				region:  opts.Region,
 				id:      aws.StringValue(stack.StackId),
 				name:    aws.StringValue(stack.StackName),
-			}
+			})
+			createdTimes = append(createdTimes, stack.CreationTime)
+		}
+		return true
+	}
+
+	if err := svc.ListStacksPages(&cf.ListStacksInput{}, pageFunc); err != nil {
+		return err
+	}
+
+	// Fetch tags and mark each candidate concurrently, so tag-lookup
+	// latency for one stack overlaps with another's; bounded by
+	// opts.Parallelism so we don't overrun CloudFormation's API rate
+	// limits.
+	var toDeleteMu sync.Mutex
+	var toDelete []*cloudFormationStack
+
+	var eg errgroup.Group
+	eg.SetLimit(parallelism(opts))
+	for i, o := range candidates {
+		o, created := o, createdTimes[i]
+		eg.Go(func() error {
 			tags, tagErr := cfs.fetchTags(svc, o.id, logger)
 			if tagErr != nil {
 				logger.Warningf("%s: failed to fetch tags: %v", o.ARN(), tagErr)
-				continue
+				return nil
 			}
-			if !set.Mark(opts, o, stack.CreationTime, tags) {
-				continue
+			if !opts.Filter.For(cloudFormationStackResourceType).Matches(o.name, tags) {
+				return nil
+			}
+
+			shouldDelete := false
+			if opts.TagBasedState {
+				var err error
+				shouldDelete, err = set.TagAndSweep(opts, o, tags, cloudFormationStackResourceType, func(tag Tag) error {
+					return cfs.tagForDeletion(opts, o.ARN(), tag)
+				})
+				if err != nil {
+					logger.Warningf("%s: failed to apply marker tag: %v", o.ARN(), err)
+					return nil
+				}
+			} else {
+				shouldDelete = set.MarkWithMeta(opts, o, created, tags, cloudFormationStackResourceType)
+			}
+			if !shouldDelete {
+				return nil
 			}
 
 			logger.Warningf("%s: deleting %T: %s", o.ARN(), o, o.name)
 			if !opts.DryRun {
+				toDeleteMu.Lock()
 				toDelete = append(toDelete, o)
+				toDeleteMu.Unlock()
 			}
-		}
-		return true
+			return nil
+		})
 	}
-
-	if err := svc.ListStacksPages(&cf.ListStacksInput{}, pageFunc); err != nil {
+	if err := eg.Wait(); err != nil {
 		return err
 	}
 
+	// DeleteStack can take minutes per stack, so run the delete phase
+	// through the same bounded worker pool; individual failures are
+	// logged per-stack rather than aborting the rest.
+	var dg errgroup.Group
+	dg.SetLimit(parallelism(opts))
 	for _, o := range toDelete {
-		if err := o.delete(svc); err != nil {
-			logger.Warningf("%s: delete failed: %v", o.ARN(), err)
-		}
+		o := o
+		dg.Go(func() error {
+			err := o.delete(svc)
+			if err != nil {
+				logger.Warningf("%s: delete failed: %v", o.ARN(), err)
+			}
+			set.RecordDeleteResult(o.ARN(), err)
+			return nil
+		})
 	}
-	return nil
+	return dg.Wait()
 }
 
 func (CloudFormationStacks) ListAll(opts Options) (*Set, error) {
@@ -0,0 +1,135 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package resources
+
+import (
+	"fmt"
+	"io/ioutil"
+	"regexp"
+	"sort"
+
+	"github.com/pkg/errors"
+	"sigs.k8s.io/yaml"
+)
+
+// knownFilterResourceTypes lists the resource-type keys a filter file may
+// use, so typos (or predicates for a type nothing consults yet) are caught
+// at load time rather than silently never matching. Add an entry here only
+// once the corresponding MarkAndSweep implementation actually calls
+// opts.Filter.For(<key>) — an accepted-but-unenforced key is worse than a
+// load-time error, since the operator has no way to notice their predicate
+// is being ignored.
+var knownFilterResourceTypes = map[string]bool{
+	cloudFormationStackResourceType: true,
+}
+
+// ResourceFilter is the set of predicates configured for a single resource
+// type in a filter file.
+type ResourceFilter struct {
+	IncludeTags TagMatcher
+	ExcludeTags TagMatcher
+	NameRegexp  *regexp.Regexp
+}
+
+// Matches reports whether a resource with the given name and tags should be
+// managed according to this ResourceFilter. A nil ResourceFilter matches
+// everything, so resource types that don't appear in the filter file are
+// left unfiltered.
+func (rf *ResourceFilter) Matches(name string, tags []Tag) bool {
+	if rf == nil {
+		return true
+	}
+	if rf.NameRegexp != nil && !rf.NameRegexp.MatchString(name) {
+		return false
+	}
+	opts := Options{IncludeTags: rf.IncludeTags, ExcludeTags: rf.ExcludeTags}
+	return opts.ManagedPerTags(tags)
+}
+
+// Filter holds, per resource-type, the predicates an operator wants applied
+// in addition to the global Options.IncludeTags/ExcludeTags. It's loaded
+// from a YAML (or JSON) file via LoadFilter and consulted by each
+// MarkAndSweep implementation through For.
+type Filter struct {
+	byType map[string]*ResourceFilter
+}
+
+// For returns the ResourceFilter configured for resourceType, or nil if the
+// filter file doesn't mention it (meaning: don't filter beyond the global
+// Options). A nil *Filter receiver also returns nil, so callers can use
+// opts.Filter.For(...) unconditionally whether or not a filter was loaded.
+func (f *Filter) For(resourceType string) *ResourceFilter {
+	if f == nil {
+		return nil
+	}
+	return f.byType[resourceType]
+}
+
+type filterFile struct {
+	Resources map[string]resourceFilterYAML `json:"resources"`
+}
+
+type resourceFilterYAML struct {
+	IncludeTags []string `json:"includeTags"`
+	ExcludeTags []string `json:"excludeTags"`
+	NameRegexp  string   `json:"nameRegexp"`
+}
+
+// LoadFilter parses a YAML or JSON filter file mapping resource-type
+// identifiers (e.g. "cloudformation_stack") to tag predicates and an
+// optional name/ID regexp.
+func LoadFilter(path string) (*Filter, error) {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, errors.Wrapf(err, "reading filter file %q", path)
+	}
+
+	var ff filterFile
+	if err := yaml.UnmarshalStrict(b, &ff); err != nil {
+		return nil, errors.Wrapf(err, "parsing filter file %q", path)
+	}
+
+	f := &Filter{byType: make(map[string]*ResourceFilter, len(ff.Resources))}
+	var unknown []string
+	for resourceType, rfy := range ff.Resources {
+		if !knownFilterResourceTypes[resourceType] {
+			unknown = append(unknown, resourceType)
+			continue
+		}
+
+		rf := &ResourceFilter{}
+		if rf.IncludeTags, err = TagMatcherForTags(rfy.IncludeTags); err != nil {
+			return nil, errors.Wrapf(err, "%s: includeTags", resourceType)
+		}
+		if rf.ExcludeTags, err = TagMatcherForTags(rfy.ExcludeTags); err != nil {
+			return nil, errors.Wrapf(err, "%s: excludeTags", resourceType)
+		}
+		if rfy.NameRegexp != "" {
+			if rf.NameRegexp, err = regexp.Compile(rfy.NameRegexp); err != nil {
+				return nil, errors.Wrapf(err, "%s: nameRegexp", resourceType)
+			}
+		}
+		f.byType[resourceType] = rf
+	}
+
+	if len(unknown) > 0 {
+		sort.Strings(unknown)
+		return nil, fmt.Errorf("filter file %q: unknown resource type(s): %v", path, unknown)
+	}
+
+	return f, nil
+}
@@ -0,0 +1,79 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package resources
+
+import (
+	"testing"
+)
+
+func TestLoadFilter(t *testing.T) {
+	f, err := LoadFilter("testdata/filter.yaml")
+	if err != nil {
+		t.Fatalf("unexpected error loading filter: %v", err)
+	}
+
+	for _, tc := range []struct {
+		Desc         string
+		ResourceType string
+		Name         string
+		Tags         []Tag
+		ShouldMatch  bool
+	}{
+		{
+			Desc:         "unfiltered resource type always matches",
+			ResourceType: "iam_role",
+			Name:         "anything",
+			ShouldMatch:  true,
+		},
+		{
+			Desc:         "name matches, no Keep tag",
+			ResourceType: "cloudformation_stack",
+			Name:         "kops-test-1234",
+			ShouldMatch:  true,
+		},
+		{
+			Desc:         "name doesn't match",
+			ResourceType: "cloudformation_stack",
+			Name:         "prod-cluster",
+			ShouldMatch:  false,
+		},
+		{
+			Desc:         "name matches but Keep tag excludes it",
+			ResourceType: "cloudformation_stack",
+			Name:         "kops-test-1234",
+			Tags:         []Tag{{"Keep", "true"}},
+			ShouldMatch:  false,
+		},
+	} {
+		got := f.For(tc.ResourceType).Matches(tc.Name, tc.Tags)
+		if got != tc.ShouldMatch {
+			t.Errorf("%s: matches: expected=%v, got=%v", tc.Desc, tc.ShouldMatch, got)
+		}
+	}
+}
+
+func TestLoadFilterUnknownResourceType(t *testing.T) {
+	if _, err := LoadFilter("testdata/filter_bad_type.yaml"); err == nil {
+		t.Fatal("expected an error for an unknown resource type, got nil")
+	}
+}
+
+func TestLoadFilterMissingFile(t *testing.T) {
+	if _, err := LoadFilter("testdata/does-not-exist.yaml"); err == nil {
+		t.Fatal("expected an error for a missing file, got nil")
+	}
+}
@@ -0,0 +1,186 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package resources
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// RegistryEntry describes one resource Type's place in the sweep order: its
+// human-readable name, the Type itself, the names of other entries it
+// depends on (and so must be swept after), and how long the driver waits
+// after sweeping this entry, in every pass, before it moves on to the next
+// entry or pass, to give AWS time to propagate this entry's deletions
+// (e.g. security group detachment) to the types swept after it.
+type RegistryEntry struct {
+	Name                     string
+	Type                     Type
+	DependsOn                []string
+	EventuallyConsistentWait time.Duration
+}
+
+// Registry holds the RegistryEntry for every resource type the driver knows
+// how to sweep, and computes a dependency-respecting sweep order.
+type Registry struct {
+	entries map[string]RegistryEntry
+}
+
+// NewRegistry returns an empty Registry.
+func NewRegistry() *Registry {
+	return &Registry{entries: make(map[string]RegistryEntry)}
+}
+
+// Register adds entry to the Registry.
+func (r *Registry) Register(entry RegistryEntry) {
+	r.entries[entry.Name] = entry
+}
+
+// DefaultRegistry is the Registry used when the driver isn't given a custom
+// one. Resource types register themselves here as they gain dependents;
+// types not yet implemented in this package (load balancers, security
+// groups, EKS clusters, autoscaling groups, ...) should add their own entry
+// alongside their MarkAndSweep implementation.
+func DefaultRegistry() *Registry {
+	r := NewRegistry()
+	r.Register(RegistryEntry{
+		Name: "cloudformation_stack",
+		Type: CloudFormationStacks{},
+	})
+	return r
+}
+
+// Order returns the registered entry names in dependency order: an entry
+// always appears after every name in its DependsOn. It returns an error if
+// an entry depends on a name that isn't registered, or if the dependency
+// graph has a cycle.
+func (r *Registry) Order() ([]string, error) {
+	const (
+		unvisited = iota
+		visiting
+		visited
+	)
+	state := make(map[string]int, len(r.entries))
+	var order []string
+
+	// Iterate registered names in a fixed order so Order is deterministic
+	// given the same registrations.
+	names := make([]string, 0, len(r.entries))
+	for name := range r.entries {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var visit func(name string, path []string) error
+	visit = func(name string, path []string) error {
+		switch state[name] {
+		case visited:
+			return nil
+		case visiting:
+			return fmt.Errorf("dependency cycle detected: %v", append(path, name))
+		}
+
+		entry, ok := r.entries[name]
+		if !ok {
+			return fmt.Errorf("%q depends on unregistered type %q", path[len(path)-1], name)
+		}
+
+		state[name] = visiting
+		deps := append([]string(nil), entry.DependsOn...)
+		sort.Strings(deps)
+		for _, dep := range deps {
+			if err := visit(dep, append(path, name)); err != nil {
+				return err
+			}
+		}
+		state[name] = visited
+		order = append(order, name)
+		return nil
+	}
+
+	for _, name := range names {
+		if err := visit(name, nil); err != nil {
+			return nil, err
+		}
+	}
+
+	return order, nil
+}
+
+// Sweep runs MarkAndSweep for every registered Type in dependency order,
+// retrying the whole pass up to maxRetries times so that transient errors
+// (e.g. DependencyViolation from a resource whose dependent hasn't finished
+// deleting yet) resolve themselves as prerequisite types are reaped first.
+// If reportPath is non-empty, the structured sweep report (see Set.Report)
+// is written there in JSON, or YAML if reportPath ends in ".yaml"/".yml",
+// once sweeping completes (successfully or not).
+func (r *Registry) Sweep(opts Options, set *Set, maxRetries int, reportPath string) error {
+	if reportPath != "" {
+		defer func() {
+			if err := writeReportFile(set, reportPath); err != nil {
+				logrus.Warningf("failed to write sweep report to %s: %v", reportPath, err)
+			}
+		}()
+	}
+
+	order, err := r.Order()
+	if err != nil {
+		return err
+	}
+
+	var lastErr error
+	for attempt := 0; attempt <= maxRetries; attempt++ {
+		lastErr = nil
+		for _, name := range order {
+			entry := r.entries[name]
+			if err := entry.Type.MarkAndSweep(opts, set); err != nil {
+				logrus.Warningf("%s: sweep attempt %d failed: %v", name, attempt, err)
+				lastErr = err
+			}
+			if entry.EventuallyConsistentWait > 0 {
+				time.Sleep(entry.EventuallyConsistentWait)
+			}
+		}
+		if lastErr == nil {
+			return nil
+		}
+	}
+	return lastErr
+}
+
+// writeReportFile writes set's structured sweep report to path, choosing
+// YAML for a ".yaml"/".yml" extension and JSON otherwise.
+func writeReportFile(set *Set, path string) error {
+	format := "json"
+	if ext := strings.ToLower(filepath.Ext(path)); ext == ".yaml" || ext == ".yml" {
+		format = "yaml"
+	}
+
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	return set.Report(f, format)
+}
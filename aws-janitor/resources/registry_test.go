@@ -0,0 +1,82 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package resources
+
+import (
+	"testing"
+)
+
+// fakeType is a no-op Type used to exercise Registry ordering without
+// needing real AWS resources.
+type fakeType struct{}
+
+func (fakeType) MarkAndSweep(Options, *Set) error { return nil }
+func (fakeType) ListAll(Options) (*Set, error)    { return NewSet(0), nil }
+
+func indexOf(order []string, name string) int {
+	for i, n := range order {
+		if n == name {
+			return i
+		}
+	}
+	return -1
+}
+
+func TestRegistryOrder(t *testing.T) {
+	r := NewRegistry()
+	r.Register(RegistryEntry{Name: "load_balancer", Type: fakeType{}})
+	r.Register(RegistryEntry{Name: "instance", Type: fakeType{}})
+	r.Register(RegistryEntry{Name: "security_group", Type: fakeType{}, DependsOn: []string{"load_balancer", "instance"}})
+	r.Register(RegistryEntry{Name: "eks_cluster", Type: fakeType{}, DependsOn: []string{"security_group"}})
+
+	order, err := r.Order()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(order) != 4 {
+		t.Fatalf("expected 4 entries in order, got %v", order)
+	}
+
+	if i, j := indexOf(order, "load_balancer"), indexOf(order, "security_group"); i >= j {
+		t.Errorf("expected load_balancer before security_group, got order %v", order)
+	}
+	if i, j := indexOf(order, "instance"), indexOf(order, "security_group"); i >= j {
+		t.Errorf("expected instance before security_group, got order %v", order)
+	}
+	if i, j := indexOf(order, "security_group"), indexOf(order, "eks_cluster"); i >= j {
+		t.Errorf("expected security_group before eks_cluster, got order %v", order)
+	}
+}
+
+func TestRegistryOrderCycle(t *testing.T) {
+	r := NewRegistry()
+	r.Register(RegistryEntry{Name: "a", Type: fakeType{}, DependsOn: []string{"b"}})
+	r.Register(RegistryEntry{Name: "b", Type: fakeType{}, DependsOn: []string{"a"}})
+
+	if _, err := r.Order(); err == nil {
+		t.Fatal("expected a cycle error, got nil")
+	}
+}
+
+func TestRegistryOrderUnknownDependency(t *testing.T) {
+	r := NewRegistry()
+	r.Register(RegistryEntry{Name: "a", Type: fakeType{}, DependsOn: []string{"missing"}})
+
+	if _, err := r.Order(); err == nil {
+		t.Fatal("expected an error for an unregistered dependency, got nil")
+	}
+}
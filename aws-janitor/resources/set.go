@@ -19,7 +19,10 @@ package resources
 import (
 	"bytes"
 	"encoding/json"
+	"fmt"
+	"io"
 	"sort"
+	"sync"
 	"time"
 
 	"github.com/aws/aws-sdk-go/aws"
@@ -27,16 +30,36 @@ import (
 	"github.com/aws/aws-sdk-go/aws/session"
 	"github.com/aws/aws-sdk-go/service/s3"
 	"github.com/sirupsen/logrus"
+	"sigs.k8s.io/yaml"
 
 	s3path "sigs.k8s.io/boskos/aws-janitor/s3"
 )
 
+// SweptResource is a structured record of one resource Set swept, suitable
+// for feeding dashboards or cost-attribution pipelines. See Set.Report.
+type SweptResource struct {
+	ARN          string     `json:"arn" yaml:"arn"`
+	ResourceType string     `json:"resourceType" yaml:"resourceType"`
+	Region       string     `json:"region" yaml:"region"`
+	Account      string     `json:"account" yaml:"account"`
+	FirstSeen    time.Time  `json:"firstSeen" yaml:"firstSeen"`
+	DeletedAt    *time.Time `json:"deletedAt,omitempty" yaml:"deletedAt,omitempty"`
+	Tags         []Tag      `json:"tags,omitempty" yaml:"tags,omitempty"`
+	DeleteError  string     `json:"deleteError,omitempty" yaml:"deleteError,omitempty"`
+}
+
 // Set keeps track of the first time we saw a particular
 // ARN, and the global TTL. See Mark() for more details.
+//
+// A Set may be shared across goroutines sweeping different resource types
+// (or a single type's parallel delete phase, see Options.Parallelism); mu
+// guards every mutable field below.
 type Set struct {
+	mu        sync.Mutex
 	firstSeen map[string]time.Time // ARN -> first time we saw
 	marked    map[string]bool      // ARN -> seen this run
 	swept     []string             // List of resources we attempted to sweep (to summarize)
+	report    map[string]*SweptResource
 	ttl       time.Duration
 }
 
@@ -44,11 +67,15 @@ func NewSet(ttl time.Duration) *Set {
 	return &Set{
 		firstSeen: make(map[string]time.Time),
 		marked:    make(map[string]bool),
+		report:    make(map[string]*SweptResource),
 		ttl:       ttl,
 	}
 }
 
 func (s *Set) GetARNs() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
 	slice := make([]string, len(s.firstSeen))
 	i := 0
 	for key := range s.firstSeen {
@@ -110,7 +137,6 @@ func (s *Set) Save(sess *session.Session, p *s3path.Path) error {
 // If the created time is not provided, the current time is used instead.
 func (s *Set) Mark(opts Options, r Interface, created *time.Time, tags []Tag) bool {
 	key := r.ResourceKey()
-	s.marked[key] = true
 
 	// Calculate the most likely creation time based on whichever is first:
 	// - the current time
@@ -122,6 +148,10 @@ func (s *Set) Mark(opts Options, r Interface, created *time.Time, tags []Tag) bo
 		firstSeen = *created
 	}
 
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.marked[key] = true
 	if t, ok := s.firstSeen[key]; ok && t.Before(firstSeen) {
 		firstSeen = t
 	}
@@ -139,10 +169,180 @@ func (s *Set) Mark(opts Options, r Interface, created *time.Time, tags []Tag) bo
 	return false
 }
 
+// MarkWithMeta is Mark, but additionally records a SweptResource (with the
+// resource's type name and tags) into the structured report whenever the
+// resource is marked for deletion. See Set.Report.
+func (s *Set) MarkWithMeta(opts Options, r Interface, created *time.Time, tags []Tag, resourceType string) bool {
+	shouldDelete := s.Mark(opts, r, created, tags)
+	if shouldDelete {
+		s.recordSwept(r, opts, tags, resourceType)
+	}
+	return shouldDelete
+}
+
+func (s *Set) recordSwept(r Interface, opts Options, tags []Tag, resourceType string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.report[r.ARN()] = &SweptResource{
+		ARN:          r.ARN(),
+		ResourceType: resourceType,
+		Region:       opts.Region,
+		Account:      opts.Account,
+		FirstSeen:    s.firstSeen[r.ResourceKey()],
+		Tags:         tags,
+	}
+}
+
+// RecordDeleteResult fills in the DeletedAt/DeleteError fields of the
+// SweptResource previously recorded (via MarkWithMeta or TagAndSweep) for
+// arn. It is a no-op if arn was never marked for deletion.
+func (s *Set) RecordDeleteResult(arn string, deleteErr error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	sr, ok := s.report[arn]
+	if !ok {
+		return
+	}
+	now := time.Now()
+	sr.DeletedAt = &now
+	if deleteErr != nil {
+		sr.DeleteError = deleteErr.Error()
+	}
+}
+
+// Report writes the structured sweep report accumulated so far to w, in
+// either "json" or "yaml" format.
+func (s *Set) Report(w io.Writer, format string) error {
+	s.mu.Lock()
+	resources := make([]*SweptResource, 0, len(s.report))
+	for _, sr := range s.report {
+		resources = append(resources, sr)
+	}
+	s.mu.Unlock()
+
+	sort.Slice(resources, func(i, j int) bool { return resources[i].ARN < resources[j].ARN })
+
+	switch format {
+	case "json":
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(resources)
+	case "yaml":
+		b, err := yaml.Marshal(resources)
+		if err != nil {
+			return err
+		}
+		_, err = w.Write(b)
+		return err
+	default:
+		return fmt.Errorf("unknown report format %q (want \"json\" or \"yaml\")", format)
+	}
+}
+
+// MarkedForDeletionTagKey is the tag key TagAndSweep uses to record, on the
+// resource itself, the RFC3339 timestamp it was first observed as eligible
+// for deletion. Keeping this timestamp on the resource (rather than only in
+// the S3-backed Set) lets concurrent janitors agree on "first seen" without
+// racing on a shared state object, and survives the state object being
+// lost.
+const MarkedForDeletionTagKey = "aws-janitor/marked-for-deletion"
+
+// TagAndSweep is an alternative to Mark for resource types that can tag
+// themselves: instead of relying solely on Set's local (S3-backed)
+// bookkeeping for "first seen", it treats the MarkedForDeletionTagKey tag on
+// the resource as the source of truth. The first time a resource is
+// observed without that tag (or with an unparseable one), applyTag is
+// called to (re-)apply it with the current time (seeded from any existing
+// local state, to migrate smoothly off of Set.Mark); on later passes the
+// tag's value is parsed instead.
+//
+// applyTag should write the given Tag onto the resource via that resource
+// type's tagging API (or the resource tagging API), and is only called
+// after confirming the resource is managed per IncludeTags/ExcludeTags/
+// IgnoreTag: a resource the operator excluded or protected must never be
+// mutated, even just to stamp it with a marker tag. resourceType is
+// recorded into the structured report (see Set.Report) when the resource
+// is marked for deletion.
+func (s *Set) TagAndSweep(opts Options, r Interface, tags []Tag, resourceType string, applyTag func(Tag) error) (bool, error) {
+	key := r.ResourceKey()
+
+	now := time.Now()
+	firstSeen := now
+	needsMarker := true
+	if marker, ok := findTag(tags, MarkedForDeletionTagKey); ok {
+		if t, err := time.Parse(time.RFC3339, marker.Value); err == nil {
+			firstSeen = t
+			needsMarker = false
+		} else {
+			logrus.Warningf("%s: invalid %s tag value %q: %v; re-stamping", key, MarkedForDeletionTagKey, marker.Value, err)
+		}
+	}
+	if needsMarker {
+		// Migration/repair path: carry forward any first-seen time we
+		// already recorded locally (e.g. from a previous Set.Mark-based
+		// run, or before this tag was corrupted) instead of restarting the
+		// clock.
+		s.mu.Lock()
+		if t, ok := s.firstSeen[key]; ok && t.Before(firstSeen) {
+			firstSeen = t
+		}
+		s.mu.Unlock()
+	}
+
+	s.mu.Lock()
+	s.marked[key] = true
+	s.firstSeen[key] = firstSeen
+	managed := opts.ManagedPerTags(tags)
+	s.mu.Unlock()
+
+	if !managed {
+		return false, nil
+	}
+
+	if needsMarker {
+		// Applied without holding mu: it's an AWS API call and shouldn't
+		// block other goroutines sharing this Set.
+		if err := applyTag(Tag{Key: MarkedForDeletionTagKey, Value: firstSeen.Format(time.RFC3339)}); err != nil {
+			return false, err
+		}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.ttl == 0 || now.Sub(firstSeen) > s.ttl {
+		s.swept = append(s.swept, key)
+		s.report[r.ARN()] = &SweptResource{
+			ARN:          r.ARN(),
+			ResourceType: resourceType,
+			Region:       opts.Region,
+			Account:      opts.Account,
+			FirstSeen:    firstSeen,
+			Tags:         tags,
+		}
+		return true, nil
+	}
+	return false, nil
+}
+
+func findTag(tags []Tag, key string) (Tag, bool) {
+	for _, t := range tags {
+		if t.Key == key {
+			return t, true
+		}
+	}
+	return Tag{}, false
+}
+
 // MarkComplete figures out which ARNs were in previous passes but not
 // this one, and eliminates them. It should only be run after all
 // resources have been marked.
 func (s *Set) MarkComplete() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
 	var gone []string
 	for key := range s.firstSeen {
 		if !s.marked[key] {
@@ -0,0 +1,56 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package resources
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+type fakeResource struct {
+	arn string
+	key string
+}
+
+func (f fakeResource) ARN() string         { return f.arn }
+func (f fakeResource) ResourceKey() string { return f.key }
+
+func TestSetReport(t *testing.T) {
+	s := NewSet(0)
+	opts := Options{Account: "1234", Region: "us-east-1"}
+	r := fakeResource{arn: "arn:aws:cloudformation:us-east-1:1234:stack/foo", key: "foo"}
+
+	if !s.MarkWithMeta(opts, r, nil, []Tag{{"Owner", "alice"}}, "cloudformation_stack") {
+		t.Fatalf("expected a zero-TTL resource to be marked for deletion")
+	}
+	s.RecordDeleteResult(r.ARN(), nil)
+
+	var buf bytes.Buffer
+	if err := s.Report(&buf, "json"); err != nil {
+		t.Fatalf("unexpected error generating report: %v", err)
+	}
+	for _, want := range []string{r.arn, "cloudformation_stack", "alice"} {
+		if !strings.Contains(buf.String(), want) {
+			t.Errorf("expected report to contain %q, got:\n%s", want, buf.String())
+		}
+	}
+
+	if err := s.Report(&buf, "xml"); err == nil {
+		t.Error("expected an error for an unknown report format, got nil")
+	}
+}
@@ -0,0 +1,164 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package resources
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/pkg/errors"
+)
+
+// Tag is a simple key/value AWS resource tag.
+type Tag struct {
+	Key   string
+	Value string
+}
+
+// NewTag builds a Tag from the *string pairs the AWS SDK returns.
+func NewTag(key, value *string) Tag {
+	return Tag{Key: aws.StringValue(key), Value: aws.StringValue(value)}
+}
+
+// tagMatchKind distinguishes the operators TagMatcherForTags understands.
+type tagMatchKind int
+
+const (
+	tagMatchAny       tagMatchKind = iota // bare "key": any value, including empty
+	tagMatchExists                        // "key=*": any non-empty value
+	tagMatchEquals                        // "key=value"
+	tagMatchNotEquals                     // "key!=value"
+	tagMatchRegexp                        // "key=~regex"
+	tagMatchNotRegexp                     // "key!~regex"
+)
+
+// tagMatch is a single compiled entry of a TagMatcher.
+type tagMatch struct {
+	key   string
+	kind  tagMatchKind
+	value string
+	re    *regexp.Regexp
+}
+
+func (m tagMatch) matchesTag(tag Tag) bool {
+	if tag.Key != m.key {
+		return false
+	}
+	switch m.kind {
+	case tagMatchAny:
+		return true
+	case tagMatchExists:
+		return tag.Value != ""
+	case tagMatchEquals:
+		return tag.Value == m.value
+	case tagMatchNotEquals:
+		return tag.Value != m.value
+	case tagMatchRegexp:
+		return m.re.MatchString(tag.Value)
+	case tagMatchNotRegexp:
+		return !m.re.MatchString(tag.Value)
+	default:
+		return false
+	}
+}
+
+// TagMatcher is a set of tag predicates, each built from an expression
+// understood by TagMatcherForTags.
+type TagMatcher struct {
+	matches []tagMatch
+}
+
+// Matches reports whether any entry of the TagMatcher matches the given
+// Tag.
+func (tm TagMatcher) Matches(tag Tag) bool {
+	for _, m := range tm.matches {
+		if m.matchesTag(tag) {
+			return true
+		}
+	}
+	return false
+}
+
+// unquote strips a single pair of surrounding double quotes, if present, so
+// values containing "=" or "," can be written as e.g. key="a=b,c".
+func unquote(s string) string {
+	if len(s) >= 2 && s[0] == '"' && s[len(s)-1] == '"' {
+		return s[1 : len(s)-1]
+	}
+	return s
+}
+
+// TagMatcherForTags builds a TagMatcher from a list of tag expressions, as
+// passed via --include-tag/--exclude-tag:
+//
+//	key            matches the key with any value (including empty)
+//	key=value      matches the key with exactly this value
+//	key=*          matches the key with any non-empty value
+//	key!=value     matches the key with any value other than this one
+//	key=~regex     matches the key when its value matches the regex
+//	key!~regex     matches the key when its value does not match the regex
+//
+// Values may be wrapped in double quotes so that "=" or "," can appear
+// literally within them.
+func TagMatcherForTags(tags []string) (TagMatcher, error) {
+	var tm TagMatcher
+	for _, t := range tags {
+		m, err := parseTagMatch(t)
+		if err != nil {
+			return TagMatcher{}, errors.Wrapf(err, "invalid tag expression %q", t)
+		}
+		tm.matches = append(tm.matches, m)
+	}
+	return tm, nil
+}
+
+func parseTagMatch(expr string) (tagMatch, error) {
+	// The key ends at the earliest operator, so an operator inside a
+	// (quoted) value is not taken for the one following the key.
+	op, i := "", -1
+	for _, o := range []string{"!~", "=~", "!=", "="} {
+		if j := strings.Index(expr, o); j >= 0 && (i < 0 || j < i) {
+			op, i = o, j
+		}
+	}
+	if op == "!~" {
+		re, err := regexp.Compile(unquote(expr[i+2:]))
+		if err != nil {
+			return tagMatch{}, errors.Wrap(err, "bad regexp")
+		}
+		return tagMatch{key: expr[:i], kind: tagMatchNotRegexp, re: re}, nil
+	}
+	if op == "=~" {
+		re, err := regexp.Compile(unquote(expr[i+2:]))
+		if err != nil {
+			return tagMatch{}, errors.Wrap(err, "bad regexp")
+		}
+		return tagMatch{key: expr[:i], kind: tagMatchRegexp, re: re}, nil
+	}
+	if op == "!=" {
+		return tagMatch{key: expr[:i], kind: tagMatchNotEquals, value: unquote(expr[i+2:])}, nil
+	}
+	if op == "=" {
+		value := unquote(expr[i+1:])
+		if value == "*" {
+			return tagMatch{key: expr[:i], kind: tagMatchExists}, nil
+		}
+		return tagMatch{key: expr[:i], kind: tagMatchEquals, value: value}, nil
+	}
+	return tagMatch{key: expr, kind: tagMatchAny}, nil
+}
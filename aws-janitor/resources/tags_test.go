@@ -27,6 +27,10 @@ func TestMatchesTag(t *testing.T) {
 		"foo=1",
 		"foo=2",
 		"bar=abc",
+		"Environment=~^(dev|staging)$",
+		"Owner=*",
+		"DoNotDelete!=true",
+		"quotedVal=\"a=b,c\"",
 	})
 	if err != nil {
 		t.Fatalf("unexpected error creating tag matcher: %v", err)
@@ -83,6 +87,42 @@ func TestMatchesTag(t *testing.T) {
 			Tag:         Tag{"bar", "xyz"},
 			ShouldMatch: false,
 		},
+		{
+			Tag:         Tag{"Environment", "dev"},
+			ShouldMatch: true,
+		},
+		{
+			Tag:         Tag{"Environment", "staging"},
+			ShouldMatch: true,
+		},
+		{
+			Tag:         Tag{"Environment", "production"},
+			ShouldMatch: false,
+		},
+		{
+			Tag:         Tag{"Owner", "alice"},
+			ShouldMatch: true,
+		},
+		{
+			Tag:         Tag{"Owner", ""},
+			ShouldMatch: false,
+		},
+		{
+			Tag:         Tag{"DoNotDelete", "false"},
+			ShouldMatch: true,
+		},
+		{
+			Tag:         Tag{"DoNotDelete", "true"},
+			ShouldMatch: false,
+		},
+		{
+			Tag:         Tag{"quotedVal", "a=b,c"},
+			ShouldMatch: true,
+		},
+		{
+			Tag:         Tag{"quotedVal", "a"},
+			ShouldMatch: false,
+		},
 	} {
 		matches := tm.Matches(tc.Tag)
 		if matches != tc.ShouldMatch {
@@ -91,6 +131,12 @@ func TestMatchesTag(t *testing.T) {
 	}
 }
 
+func TestTagMatcherForTagsBadRegexp(t *testing.T) {
+	if _, err := TagMatcherForTags([]string{"Environment=~("}); err == nil {
+		t.Fatal("expected an error for an invalid regexp, got nil")
+	}
+}
+
 func TestManagedPerTags(t *testing.T) {
 	// These tags and matchers aren't using values, since we test that in the other unit test.
 	metasynTags := []Tag{{"foo", ""}, {"bar", ""}, {"baz", ""}}
@@ -113,6 +159,7 @@ func TestManagedPerTags(t *testing.T) {
 		Tags         []Tag
 		IncludeTags  TagMatcher
 		ExcludeTags  TagMatcher
+		IgnoreTag    string
 		ShouldManage bool
 	}{
 		{
@@ -182,10 +229,35 @@ func TestManagedPerTags(t *testing.T) {
 			ExcludeTags:  tmRGB,
 			ShouldManage: false,
 		},
+		{
+			Desc:         "ignore tag present overrides include match",
+			Tags:         append(metasynTags, Tag{"protected", ""}),
+			IncludeTags:  tmBar,
+			ExcludeTags:  tmEmpty,
+			IgnoreTag:    "protected",
+			ShouldManage: false,
+		},
+		{
+			Desc:         "ignore tag present alongside an exclude match stays unmanaged",
+			Tags:         append(colorTags, Tag{"protected", ""}),
+			IncludeTags:  tmEmpty,
+			ExcludeTags:  tmRGB,
+			IgnoreTag:    "protected",
+			ShouldManage: false,
+		},
+		{
+			Desc:         "ignore tag configured but absent has no effect",
+			Tags:         metasynTags,
+			IncludeTags:  tmBar,
+			ExcludeTags:  tmEmpty,
+			IgnoreTag:    "protected",
+			ShouldManage: true,
+		},
 	} {
 		opts := Options{
 			IncludeTags: tc.IncludeTags,
 			ExcludeTags: tc.ExcludeTags,
+			IgnoreTag:   tc.IgnoreTag,
 		}
 		managed := opts.ManagedPerTags(tc.Tags)
 		if managed != tc.ShouldManage {
@@ -0,0 +1,116 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package resources
+
+import (
+	"github.com/aws/aws-sdk-go/aws/session"
+)
+
+// Interface is implemented by every resource wrapper type (e.g.
+// cloudFormationStack) so it can be handed to Set.Mark.
+type Interface interface {
+	// ARN returns the AWS ARN for the resource.
+	ARN() string
+	// ResourceKey returns the key Set uses to track the resource across
+	// runs (for a CloudFormation stack, its name).
+	ResourceKey() string
+}
+
+// Type is implemented by each resource type (e.g. CloudFormationStacks) that
+// the janitor knows how to sweep.
+type Type interface {
+	// MarkAndSweep marks resources that should be deleted, and deletes
+	// those that are past their TTL.
+	MarkAndSweep(opts Options, set *Set) error
+	// ListAll returns every resource of this type, regardless of TTL, for
+	// bootstrapping/migrating the Set's state.
+	ListAll(opts Options) (*Set, error)
+}
+
+// Options carries the configuration shared across all resource types'
+// MarkAndSweep/ListAll implementations.
+type Options struct {
+	Session *session.Session
+	Account string
+	Region  string
+	DryRun  bool
+
+	// IncludeTags, if non-empty, requires a resource to carry every tag it
+	// describes before it is managed (AND semantics across entries).
+	IncludeTags TagMatcher
+	// ExcludeTags, if any entry matches any tag on the resource, exempts
+	// the resource from management (OR semantics across entries).
+	ExcludeTags TagMatcher
+
+	// TagBasedState, if set, makes resource types use Set.TagAndSweep
+	// instead of Set.Mark: the "first seen" timestamp is stored as a tag
+	// on the resource itself rather than in the shared S3 state object.
+	TagBasedState bool
+
+	// IgnoreTag, if set, names a tag key that unconditionally exempts a
+	// resource from management, regardless of IncludeTags/ExcludeTags or
+	// TTL: any tag with this key, whatever its value, protects the
+	// resource. This is the standard opt-out operators use to protect
+	// long-lived shared infra (bastions, DNS zones, CI IAM roles) living in
+	// the same account as ephemeral test resources.
+	IgnoreTag string
+
+	// Parallelism bounds how many resources a MarkAndSweep implementation
+	// may delete concurrently. A value of 0 or 1 preserves the historical
+	// serial behavior.
+	Parallelism int
+
+	// Filter, if set, supplies additional per-resource-type predicates
+	// (see Filter.For) that MarkAndSweep implementations consult alongside
+	// IncludeTags/ExcludeTags.
+	Filter *Filter
+}
+
+// ManagedPerTags reports whether a resource carrying the given tags should
+// be managed (i.e. considered for deletion) by the janitor, based on
+// IgnoreTag (checked first), IncludeTags and ExcludeTags. It does not
+// consider the TTL.
+func (o Options) ManagedPerTags(tags []Tag) bool {
+	if o.IgnoreTag != "" {
+		for _, tag := range tags {
+			if tag.Key == o.IgnoreTag {
+				return false
+			}
+		}
+	}
+
+	for _, m := range o.IncludeTags.matches {
+		found := false
+		for _, tag := range tags {
+			if m.matchesTag(tag) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+
+	for _, tag := range tags {
+		if o.ExcludeTags.Matches(tag) {
+			return false
+		}
+	}
+
+	return true
+}